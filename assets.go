@@ -0,0 +1,346 @@
+package epub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	fontFolderName = "font"
+	cssFolderName  = "css"
+
+	maxConcurrentFetches = 4
+
+	assetCacheFilePerm = 0644
+	assetCacheDirPerm  = 0775
+)
+
+// assetKind distinguishes the kinds of assets Add* can register, since
+// only images are validated against their MIME type.
+type assetKind int
+
+const (
+	assetKindImage assetKind = iota
+	assetKindFont
+	assetKindCSS
+)
+
+// pendingAsset is a remote asset registered by Add* but not yet
+// downloaded. It's fetched lazily by fetchPendingAssets when Write or
+// WriteTo is called, rather than blocking the Add* call on the network.
+type pendingAsset struct {
+	kind assetKind
+	url  string
+	href string
+}
+
+// ErrInvalidImageType is returned by AddImage when the asset read from
+// disk or fetched from a URL isn't an image, so callers can distinguish a
+// validation failure from a network failure.
+type ErrInvalidImageType struct {
+	MediaType string
+}
+
+func (e *ErrInvalidImageType) Error() string {
+	return fmt.Sprintf("asset is not an image (detected media type %q)", e.MediaType)
+}
+
+// AddImage registers source (a local file path or an http(s) URL) as an
+// image in the EPUB, using filename as its manifest href, or a generated
+// one if filename is "". It returns the href the image will be stored
+// under.
+func (e *epub) AddImage(source, filename string) (string, error) {
+	return e.addAsset(assetKindImage, source, filename, imageFolderName)
+}
+
+// AddFont registers source (a local file path or an http(s) URL) as a font
+// in the EPUB. See AddImage for the filename and href semantics.
+func (e *epub) AddFont(source, filename string) (string, error) {
+	return e.addAsset(assetKindFont, source, filename, fontFolderName)
+}
+
+// AddCSS registers source (a local file path or an http(s) URL) as a
+// stylesheet in the EPUB. See AddImage for the filename and href
+// semantics.
+func (e *epub) AddCSS(source, filename string) (string, error) {
+	return e.addAsset(assetKindCSS, source, filename, cssFolderName)
+}
+
+// SetHTTPClient overrides the http.Client used to fetch remote assets
+// registered via AddImage/AddFont/AddCSS, so callers can set timeouts,
+// proxies, or auth headers.
+func (e *epub) SetHTTPClient(client *http.Client) {
+	e.httpClient = client
+}
+
+// SetAssetCache enables a local content-addressed cache under dir, keyed
+// by URL and ETag, so builds that repeatedly reference the same remote
+// assets (e.g. many pages sharing a header image) don't re-download them.
+func (e *epub) SetAssetCache(dir string) {
+	e.assetCacheDir = dir
+}
+
+// addAsset is the common path for AddImage/AddFont/AddCSS: local sources
+// are read and validated immediately, remote sources are only registered
+// and fetched lazily when Write/WriteTo runs.
+func (e *epub) addAsset(kind assetKind, source, filename, folder string) (string, error) {
+	if filename == "" {
+		filename = e.nextAssetFilename(source, folder)
+	}
+	href := filepath.ToSlash(filepath.Join(folder, filename))
+
+	if e.hasAsset(href) {
+		return "", tagError(ErrDuplicateFilename, fmt.Errorf("%q is already registered", href))
+	}
+
+	if isRemoteSource(source) {
+		e.pendingAssets = append(e.pendingAssets, pendingAsset{kind: kind, url: source, href: href})
+		return href, nil
+	}
+
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return "", fmt.Errorf("reading asset %s: %w", source, err)
+	}
+
+	if kind == assetKindImage {
+		if mediaType := http.DetectContentType(data); !strings.HasPrefix(mediaType, "image/") {
+			return "", &ErrInvalidImageType{MediaType: mediaType}
+		}
+	}
+
+	// Only register the manifest entry once the data is actually in hand
+	// and validated, so a failed read or a rejected content type doesn't
+	// leave a dangling manifest item with nothing backing it in e.assets.
+	e.pkgdoc.addManifestItem(manifestIDForHref(href), href, mimeTypeForExt(filepath.Ext(href)), "")
+	e.assets[href] = data
+
+	return href, nil
+}
+
+// nextAssetFilename generates the next unused auto-assigned asset
+// filename for folder, so it doesn't collide with an explicit filename
+// used for an earlier asset, or one already present in a book loaded via
+// Read.
+func (e *epub) nextAssetFilename(source, folder string) string {
+	ext := extForSource(source)
+	for {
+		e.assetCount++
+		filename := fmt.Sprintf("asset%04d%s", e.assetCount, ext)
+		href := filepath.ToSlash(filepath.Join(folder, filename))
+		if !e.hasAsset(href) {
+			return filename
+		}
+	}
+}
+
+// hasAsset reports whether href is already registered, either as a
+// resolved asset or one still pending a remote fetch.
+func (e *epub) hasAsset(href string) bool {
+	if _, ok := e.assets[href]; ok {
+		return true
+	}
+	for _, p := range e.pendingAssets {
+		if p.href == href {
+			return true
+		}
+	}
+	return false
+}
+
+func isRemoteSource(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// extForSource returns the filename extension for source, which may be a
+// local path or a remote URL. Remote URLs are parsed so a query string
+// (e.g. a signed CDN URL like ".../cover.png?sig=...&exp=...") doesn't end
+// up baked into the extension, and from there into the generated manifest
+// href.
+func extForSource(source string) string {
+	if !isRemoteSource(source) {
+		return filepath.Ext(source)
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return filepath.Ext(source)
+	}
+	return filepath.Ext(u.Path)
+}
+
+func manifestIDForHref(href string) string {
+	return strings.NewReplacer("/", "-", ".", "-").Replace(href)
+}
+
+func mimeTypeForExt(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	case ".svg":
+		return "image/svg+xml"
+	case ".ttf":
+		return "application/x-font-ttf"
+	case ".otf":
+		return "application/x-font-otf"
+	case ".woff":
+		return "font/woff"
+	case ".woff2":
+		return "font/woff2"
+	case ".css":
+		return "text/css"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// fetchPendingAssets downloads every asset registered via AddImage/AddFont
+// /AddCSS with a remote URL, using a bounded worker pool so a book with
+// many shared assets doesn't open unbounded concurrent connections.
+func (e *epub) fetchPendingAssets() error {
+	if len(e.pendingAssets) == 0 {
+		return nil
+	}
+
+	client := e.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	type result struct {
+		href string
+		data []byte
+		err  error
+	}
+
+	jobs := make(chan pendingAsset)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < maxConcurrentFetches; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				data, err := e.fetchAsset(client, p)
+				results <- result{href: p.href, data: data, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, p := range e.pendingAssets {
+			jobs <- p
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	for r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		// As with the local-file path in addAsset, only register the
+		// manifest entry once the fetch has actually succeeded, so a
+		// failed download doesn't leave a dangling manifest item.
+		e.pkgdoc.addManifestItem(manifestIDForHref(r.href), r.href, mimeTypeForExt(filepath.Ext(r.href)), "")
+		e.assets[r.href] = r.data
+	}
+
+	e.pendingAssets = nil
+
+	return firstErr
+}
+
+// fetchAsset downloads a single pending asset, consulting the asset cache
+// first and validating the content type for images.
+func (e *epub) fetchAsset(client *http.Client, p pendingAsset) ([]byte, error) {
+	if e.assetCacheDir != "" {
+		if data, ok := e.readAssetCache(p.url); ok {
+			return data, nil
+		}
+	}
+
+	resp, err := client.Get(p.url)
+	if err != nil {
+		return nil, tagError(ErrAssetFetch, fmt.Errorf("fetching asset %s: %w", p.url, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, tagError(ErrAssetFetch, fmt.Errorf("fetching asset %s: unexpected status %s", p.url, resp.Status))
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, tagError(ErrAssetFetch, fmt.Errorf("reading asset %s: %w", p.url, err))
+	}
+
+	mediaType := resp.Header.Get("Content-Type")
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+	}
+	if p.kind == assetKindImage && !strings.HasPrefix(mediaType, "image/") {
+		return nil, &ErrInvalidImageType{MediaType: mediaType}
+	}
+
+	if e.assetCacheDir != "" {
+		e.writeAssetCache(p.url, resp.Header.Get("ETag"), data)
+	}
+
+	return data, nil
+}
+
+func (e *epub) readAssetCache(url string) ([]byte, bool) {
+	etag, err := ioutil.ReadFile(e.assetCacheETagPath(url))
+	if err != nil {
+		return nil, false
+	}
+
+	data, err := ioutil.ReadFile(e.assetCacheDataPath(url, string(etag)))
+	if err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+func (e *epub) writeAssetCache(url, etag string, data []byte) {
+	if err := os.MkdirAll(e.assetCacheDir, assetCacheDirPerm); err != nil {
+		return
+	}
+	ioutil.WriteFile(e.assetCacheETagPath(url), []byte(etag), assetCacheFilePerm)
+	ioutil.WriteFile(e.assetCacheDataPath(url, etag), data, assetCacheFilePerm)
+}
+
+func (e *epub) assetCacheETagPath(url string) string {
+	return filepath.Join(e.assetCacheDir, hashKey(url)+".etag")
+}
+
+func (e *epub) assetCacheDataPath(url, etag string) string {
+	return filepath.Join(e.assetCacheDir, hashKey(url+"|"+etag)+".asset")
+}
+
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
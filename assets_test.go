@@ -0,0 +1,149 @@
+package epub
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fmartingr/go-epub/validate"
+)
+
+const testNonImageSource = "testdata/not-an-image.txt"
+
+func TestAddImageRejectsNonImage(t *testing.T) {
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	_, err = e.AddImage(testNonImageSource, "")
+	if err == nil {
+		t.Fatal("Expected an error adding a non-image as an image, got nil")
+	}
+	if _, ok := err.(*ErrInvalidImageType); !ok {
+		t.Errorf("Expected *ErrInvalidImageType, got %T: %s", err, err)
+	}
+}
+
+func TestAddImageRejectsNonImageWithoutCorruptingManifest(t *testing.T) {
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	if _, err := e.AddImage(testNonImageSource, ""); err == nil {
+		t.Fatal("Expected an error adding a non-image as an image, got nil")
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+
+	report, err := validate.Validate(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error validating EPUB: %s", err)
+	}
+	if report.HasErrors() {
+		t.Errorf("Expected no validation errors from a rejected AddImage, got: %+v", report.Issues)
+	}
+}
+
+func TestAddImageLocalFile(t *testing.T) {
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	href, err := e.AddImage(testCoverImageSource, "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	if !strings.HasPrefix(href, imageFolderName+"/") {
+		t.Errorf("Image href %q isn't under %q", href, imageFolderName)
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+}
+
+func TestAddImageFromURLFetchesLazilyAndCaches(t *testing.T) {
+	imageData, err := ioutil.ReadFile(testCoverImageSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading test image: %s", err)
+	}
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("ETag", `"fixed-etag"`)
+		w.Write(imageData)
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+	e.SetAssetCache(cacheDir)
+
+	_, err = e.AddImage(server.URL+"/cover.png", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding remote image: %s", err)
+	}
+	if atomic.LoadInt32(&requests) != 0 {
+		t.Fatal("AddImage should not fetch the URL until Write/WriteTo is called")
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("Expected exactly 1 request after first WriteTo, got %d", requests)
+	}
+
+	e2, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+	e2.SetAssetCache(cacheDir)
+
+	if _, err := e2.AddImage(server.URL+"/cover.png", ""); err != nil {
+		t.Fatalf("Unexpected error adding remote image: %s", err)
+	}
+
+	var buf2 bytes.Buffer
+	if _, err := e2.WriteTo(&buf2); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("Expected the second EPUB to be served from cache with no new request, got %d total requests", requests)
+	}
+}
+
+func TestAddImageFromURLWithQueryStringGeneratesCleanHref(t *testing.T) {
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	href, err := e.AddImage("https://cdn.example.com/cover.png?sig=abc&exp=123", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding remote image: %s", err)
+	}
+	if strings.ContainsAny(href, "?&") {
+		t.Errorf("href %q carries query-string characters from the source URL", href)
+	}
+	if !strings.HasSuffix(href, ".png") {
+		t.Errorf("href %q should keep the .png extension from the URL path", href)
+	}
+}
@@ -0,0 +1,43 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+)
+
+// File and folder names used inside the generated EPUB archive. These are
+// fixed by the EPUB spec (mimetype, META-INF/container.xml) or are simply
+// the layout this package has always written its own generated content
+// under.
+const (
+	containerFilename = "container.xml"
+	contentFolderName = "EPUB"
+	imageFolderName   = "image"
+	metaInfFolderName = "META-INF"
+	mimetypeFilename  = "mimetype"
+	navFilename       = "nav.xhtml"
+	pkgFilename       = "package.opf"
+	tocNcxFilename    = "toc.ncx"
+	xhtmlFolderName   = "xhtml"
+
+	mimetypeContents = "application/epub+zip"
+)
+
+// containerTemplate renders META-INF/container.xml, which does nothing
+// more than point readers at the package document.
+const containerTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="%s/%s" media-type="application/oebps-package+xml" />
+  </rootfiles>
+</container>`
+
+// xmlEscapeText escapes user-supplied strings (titles, author names, TOC
+// entries, ...) before they're interpolated into the XML templates in
+// this package, so a value containing &, <, >, or " doesn't produce
+// unparsable XML.
+func xmlEscapeText(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
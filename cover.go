@@ -0,0 +1,79 @@
+package epub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+)
+
+const (
+	coverImageID       = "cover-image"
+	coverImageFilename = "cover"
+	coverXhtmlID       = "cover"
+	coverXhtmlFilename = "cover.xhtml"
+
+	coverXhtmlTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+  <head>
+    <title>%s</title>
+%s  </head>
+  <body>
+    <img src="../%s" alt="Cover"/>
+  </body>
+</html>`
+
+	coverCSSLinkTemplate = "    <link rel=\"stylesheet\" type=\"text/css\" href=\"%s\"/>\n"
+)
+
+// SetCover registers source as the book's cover image and generates an
+// XHTML wrapper page for it, optionally linking cssPath for styling. It
+// returns the href the cover image was stored under.
+//
+// The package document gets both the EPUB3 form (a manifest item with
+// properties="cover-image") and the legacy EPUB2 form (a <meta
+// name="cover"> in <metadata>), so the cover shows up in older readers
+// like Kindle's KindleGen and Calibre as well as EPUB3-only ones. The
+// cover XHTML is inserted as the first spine item with linear="no", since
+// it isn't part of the book's normal reading order, and is linked from the
+// nav document like any other section.
+func (e *epub) SetCover(source, cssPath string) (string, error) {
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return "", fmt.Errorf("reading cover image: %w", err)
+	}
+
+	mediaType := http.DetectContentType(data)
+	ext := filepath.Ext(source)
+	imageHref := filepath.ToSlash(filepath.Join(imageFolderName, coverImageFilename+ext))
+	coverHref := filepath.ToSlash(filepath.Join(xhtmlFolderName, coverXhtmlFilename))
+
+	if e.hasAsset(imageHref) || e.hasAsset(coverHref) {
+		return "", tagError(ErrDuplicateFilename, fmt.Errorf("cover is already registered"))
+	}
+
+	e.assets[imageHref] = data
+	e.pkgdoc.addManifestItem(coverImageID, imageHref, mediaType, "cover-image")
+	e.pkgdoc.setCoverMetaID(coverImageID)
+
+	var cssLink string
+	if cssPath != "" {
+		cssLink = fmt.Sprintf(coverCSSLinkTemplate, cssPath)
+	}
+	e.assets[coverHref] = []byte(fmt.Sprintf(coverXhtmlTemplate, xmlEscapeText(e.title), cssLink, imageHref))
+	e.pkgdoc.addManifestItem(coverXhtmlID, coverHref, "application/xhtml+xml", "")
+
+	e.pkgdoc.addSpineItemFirst(coverXhtmlID, "no")
+	e.toc.addEntryFirst("Cover", coverHref)
+
+	e.cover = coverHref
+
+	return coverHref, nil
+}
+
+// Cover returns the href of the cover XHTML page set via SetCover, or ""
+// if no cover has been set.
+func (e *epub) Cover() string {
+	return e.cover
+}
@@ -0,0 +1,72 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testCoverImageSource = "testdata/cover.png"
+
+func TestSetCoverAddsDualMetadata(t *testing.T) {
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	coverHref, err := e.SetCover(testCoverImageSource, "")
+	if err != nil {
+		t.Fatalf("Unexpected error setting cover: %s", err)
+	}
+	if e.Cover() != coverHref {
+		t.Errorf("Cover() is %q, expected %q", e.Cover(), coverHref)
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+
+	pkg, err := e.pkgdoc.bytes()
+	if err != nil {
+		t.Fatalf("Unexpected error rendering package document: %s", err)
+	}
+
+	if !strings.Contains(string(pkg), `properties="cover-image"`) {
+		t.Errorf("package document is missing the EPUB3 cover-image manifest property:\n%s", pkg)
+	}
+	if !strings.Contains(string(pkg), `<meta name="cover" content="cover-image"/>`) {
+		t.Errorf("package document is missing the legacy EPUB2 cover meta element:\n%s", pkg)
+	}
+	if !strings.Contains(string(pkg), `<itemref idref="cover" linear="no"/>`) {
+		t.Errorf("cover isn't the first spine item with linear=\"no\":\n%s", pkg)
+	}
+
+	navBytes, err := e.toc.navBytes()
+	if err != nil {
+		t.Fatalf("Unexpected error rendering nav document: %s", err)
+	}
+	if !strings.Contains(string(navBytes), coverHref) {
+		t.Errorf("nav document doesn't link the cover page:\n%s", navBytes)
+	}
+}
+
+func TestSetCoverRejectsDuplicate(t *testing.T) {
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	if _, err := e.SetCover(testCoverImageSource, ""); err != nil {
+		t.Fatalf("Unexpected error setting cover: %s", err)
+	}
+
+	_, err = e.SetCover(testCoverImageSource, "")
+	if err == nil {
+		t.Fatal("Expected an error calling SetCover a second time, got nil")
+	}
+	if !errors.Is(err, ErrDuplicateFilename) {
+		t.Errorf("Expected ErrDuplicateFilename, got %s", err)
+	}
+}
@@ -1,24 +1,64 @@
 package epub
 
-import "github.com/satori/go.uuid"
+import (
+	"archive/zip"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/satori/go.uuid"
+)
 
 const (
 	urnUuid = "urn:uuid:"
 )
 
+// Write progress stages reported via ProgressFunc, in the order WriteTo
+// writes them.
+const (
+	stageMimetype   = "mimetype"
+	stageContainer  = "container"
+	stagePackageDoc = "package-document"
+	stageNav        = "nav"
+	stageToc        = "toc"
+
+	// totalWriteStages is the number of fixed bookkeeping files WriteTo
+	// writes before it gets to the book's own content assets.
+	totalWriteStages = 5
+)
+
+// ProgressFunc is called once per file as WriteTo streams an EPUB, so
+// callers generating large books (or serving them during a request) can
+// surface progress to users instead of blocking silently.
+type ProgressFunc func(stage string, current, total int64)
+
 type epub struct {
-	author string
-	lang   string
-	pkgdoc *pkgdoc
-	title  string
-	toc    *toc
-	uuid   string
+	assetCacheDir string
+	assetCount    int
+	author        string
+	assets        map[string][]byte
+	cover         string
+	httpClient    *http.Client
+	lang          string
+	pendingAssets []pendingAsset
+	pkgdoc        *pkgdoc
+	progressFunc  ProgressFunc
+	sectionCount  int
+	title         string
+	toc           *toc
+	uuid          string
 }
 
 func NewEpub(title string) (*epub, error) {
 	var err error
 
 	e := &epub{}
+	e.assets = map[string][]byte{}
 	e.pkgdoc = newPkgdoc()
 	e.toc, err = newToc()
 	if err != nil {
@@ -32,11 +72,16 @@ func NewEpub(title string) (*epub, error) {
 	return e, nil
 }
 
+func (e *epub) Author() string {
+	return e.author
+}
+
 func (e *epub) Lang() string {
 	return e.lang
 }
 
 func (e *epub) SetAuthor(author string) {
+	e.author = author
 	e.pkgdoc.setAuthor(author)
 }
 
@@ -45,22 +90,185 @@ func (e *epub) SetLang(lang string) {
 	e.pkgdoc.setLang(lang)
 }
 
+// SetProgressFunc registers a callback invoked once per file as WriteTo
+// streams the EPUB, letting long-running builds report progress back to
+// the caller (e.g. a progress bar while an EPUB is generated on the fly).
+func (e *epub) SetProgressFunc(fn ProgressFunc) {
+	e.progressFunc = fn
+}
+
 func (e *epub) SetTitle(title string) {
 	e.title = title
 	e.pkgdoc.setTitle(title)
 	e.toc.setTitle(title)
 }
 
+// SetUUID sets the book's unique identifier, accepting either a bare UUID
+// or one already in "urn:uuid:" form. UUID returns it back exactly as
+// passed in, but the package document and NCX always get the urn:uuid:
+// form, since that's what the EPUB spec expects there.
 func (e *epub) SetUUID(uuid string) {
 	e.uuid = uuid
-	e.pkgdoc.setUUID(uuid)
-	e.toc.setUUID(uuid)
+	e.pkgdoc.setUUID(ensureURNUUID(uuid))
+	e.toc.setUUID(ensureURNUUID(uuid))
+}
+
+// ensureURNUUID prefixes uuid with "urn:uuid:" unless it's already in that
+// form.
+func ensureURNUUID(uuid string) string {
+	if strings.HasPrefix(uuid, urnUuid) {
+		return uuid
+	}
+	return urnUuid + uuid
 }
 
 func (e *epub) Title() string {
 	return e.title
 }
 
-func (e *epub) Uuid() string {
+func (e *epub) UUID() string {
 	return e.uuid
 }
+
+// Write generates the EPUB and writes it to the file at path, creating or
+// truncating it as needed.
+func (e *epub) Write(path string) (err error) {
+	f, createErr := os.Create(path)
+	if createErr != nil {
+		return fmt.Errorf("creating EPUB file: %w", createErr)
+	}
+	defer closeAndSetErr(f, &err)
+
+	_, err = e.WriteTo(f)
+	return err
+}
+
+// WriteTo streams the generated EPUB into w, returning the number of bytes
+// written. It lets callers produce EPUBs directly into an HTTP response, an
+// in-memory buffer, or any other io.Writer, without going through a
+// temporary file on disk.
+func (e *epub) WriteTo(w io.Writer) (int64, error) {
+	if err := e.fetchPendingAssets(); err != nil {
+		return 0, err
+	}
+
+	// Progress is reported against every file WriteTo writes, including
+	// one step per content asset, since those are what actually take
+	// meaningful time for a large book.
+	total := int64(totalWriteStages + len(e.assets))
+
+	cw := &countingWriter{w: w}
+	zw := zip.NewWriter(cw)
+
+	// EPUB requires that mimetype be the first entry in the zip, stored
+	// uncompressed with no extra fields, so readers can identify the
+	// format by sniffing the first bytes of the archive.
+	if err := writeMimetype(zw); err != nil {
+		return cw.n, err
+	}
+	e.reportProgress(stageMimetype, 1, total)
+
+	files := []struct {
+		stage string
+		path  string
+		body  func() ([]byte, error)
+	}{
+		{stageContainer, filepath.Join(metaInfFolderName, containerFilename), containerBytes},
+		{stagePackageDoc, filepath.Join(contentFolderName, pkgFilename), e.pkgdoc.bytes},
+		{stageNav, filepath.Join(contentFolderName, navFilename), e.toc.navBytes},
+		{stageToc, filepath.Join(contentFolderName, tocNcxFilename), e.toc.ncxBytes},
+	}
+
+	for i, file := range files {
+		body, err := file.body()
+		if err != nil {
+			return cw.n, fmt.Errorf("rendering %s: %w", file.path, err)
+		}
+
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:   filepath.ToSlash(file.path),
+			Method: zip.Deflate,
+		})
+		if err != nil {
+			return cw.n, err
+		}
+		if _, err := fw.Write(body); err != nil {
+			return cw.n, err
+		}
+
+		e.reportProgress(file.stage, int64(i+2), total)
+	}
+
+	// Content files loaded by Read, or added via Add* helpers, are opaque
+	// blobs by this point and just get copied into the archive verbatim.
+	// Hrefs are sorted so WriteTo's output (and the progress it reports)
+	// doesn't depend on Go's random map iteration order.
+	hrefs := make([]string, 0, len(e.assets))
+	for href := range e.assets {
+		hrefs = append(hrefs, href)
+	}
+	sort.Strings(hrefs)
+
+	for i, href := range hrefs {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:   filepath.ToSlash(filepath.Join(contentFolderName, href)),
+			Method: zip.Deflate,
+		})
+		if err != nil {
+			return cw.n, err
+		}
+		if _, err := fw.Write(e.assets[href]); err != nil {
+			return cw.n, err
+		}
+
+		e.reportProgress(href, int64(totalWriteStages+i+1), total)
+	}
+
+	if err := zw.Close(); err != nil {
+		return cw.n, err
+	}
+
+	return cw.n, nil
+}
+
+func (e *epub) reportProgress(stage string, current, total int64) {
+	if e.progressFunc != nil {
+		e.progressFunc(stage, current, total)
+	}
+}
+
+// writeMimetype writes the mandatory first entry of the zip archive. It is
+// stored rather than deflated, with its CRC set up front, so tools that
+// identify EPUBs by sniffing the first bytes of the zip find the mimetype
+// uncompressed as the spec requires.
+func writeMimetype(zw *zip.Writer) error {
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:   mimetypeFilename,
+		Method: zip.Store,
+		CRC32:  crc32.ChecksumIEEE([]byte(mimetypeContents)),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write([]byte(mimetypeContents))
+	return err
+}
+
+// containerBytes renders META-INF/container.xml, which simply points
+// readers at the package document.
+func containerBytes() ([]byte, error) {
+	return []byte(fmt.Sprintf(containerTemplate, contentFolderName, pkgFilename)), nil
+}
+
+// countingWriter wraps an io.Writer to track the number of bytes written,
+// so WriteTo can report its own total without archive/zip exposing one.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -13,6 +13,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/fmartingr/go-epub/validate"
 )
 
 const (
@@ -70,7 +72,10 @@ const (
 )
 
 func TestEpubWrite(t *testing.T) {
-	e := NewEpub(testEpubTitle)
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
 
 	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
 
@@ -122,8 +127,11 @@ func TestEpubWrite(t *testing.T) {
 }
 
 func TestAddImage(t *testing.T) {
-	e := NewEpub(testEpubTitle)
-	_, err := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+	_, err = e.AddImage(testImageFromFileSource, testImageFromFileFilename)
 	if err != nil {
 		t.Errorf("Error adding image: %s", err)
 	}
@@ -156,7 +164,7 @@ func TestAddImage(t *testing.T) {
 
 	resp, err := http.Get(testImageFromURLSource)
 	if err != nil {
-		t.Errorf("Unexpected error response from test image URL: %s", err)
+		t.Fatalf("Unexpected error response from test image URL: %s", err)
 	}
 	testImageContents, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -170,8 +178,11 @@ func TestAddImage(t *testing.T) {
 }
 
 func TestAddSection(t *testing.T) {
-	e := NewEpub(testEpubTitle)
-	_, err := e.AddSection(testSectionTitle, testSectionBody, testSectionFilename)
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+	_, err = e.AddSection(testSectionTitle, testSectionBody, testSectionFilename)
 	if err != nil {
 		t.Errorf("Error adding section: %s", err)
 	}
@@ -216,7 +227,10 @@ func TestAddSection(t *testing.T) {
 }
 
 func TestEpubAuthor(t *testing.T) {
-	e := NewEpub(testEpubTitle)
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
 	e.SetAuthor(testEpubAuthor)
 
 	if e.Author() != testEpubAuthor {
@@ -249,7 +263,10 @@ func TestEpubAuthor(t *testing.T) {
 }
 
 func TestEpubLang(t *testing.T) {
-	e := NewEpub(testEpubTitle)
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
 	e.SetLang(testEpubLang)
 
 	if e.Lang() != testEpubLang {
@@ -283,7 +300,10 @@ func TestEpubLang(t *testing.T) {
 
 func TestEpubTitle(t *testing.T) {
 	// First, test the title we provide when creating the epub
-	e := NewEpub(testEpubTitle)
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
 	if e.Title() != testEpubTitle {
 		t.Errorf(
 			"Title doesn't match\n"+
@@ -344,8 +364,38 @@ func TestEpubTitle(t *testing.T) {
 	cleanup(testEpubFilename, tempDir)
 }
 
+// TestEpubTitleWithXMLSpecialCharsIsEscaped guards against an ordinary
+// title like "Tom & Jerry" producing an unparsable package document, nav
+// document, or NCX.
+func TestEpubTitleWithXMLSpecialCharsIsEscaped(t *testing.T) {
+	e, err := NewEpub(`Tom & Jerry <3 "Fun"`)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+	e.SetAuthor(`A & B`)
+	if _, err := e.AddSection(`Chapter & Verse`, "<p>body</p>", ""); err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+
+	report, err := validate.Validate(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error validating EPUB: %s", err)
+	}
+	if report.HasErrors() {
+		t.Errorf("Expected no validation errors for a title/author/section title with XML special characters, got: %+v", report.Issues)
+	}
+}
+
 func TestEpubUUID(t *testing.T) {
-	e := NewEpub(testEpubTitle)
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
 	e.SetUUID(testEpubUUID)
 
 	if e.UUID() != testEpubUUID {
@@ -378,7 +428,10 @@ func TestEpubUUID(t *testing.T) {
 }
 
 func TestEpubValidity(t *testing.T) {
-	e := NewEpub(testEpubTitle)
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
 	e.AddImage(testImageFromFileSource, testImageFromFileFilename)
 	e.AddImage(testImageFromURLSource, "")
 	e.AddSection(testSectionTitle, testSectionBody, testSectionFilename)
@@ -421,7 +474,7 @@ func trimAllSpace(s string) string {
 }
 
 // UnzipFile unzips a file located at sourceFilePath to the provided destination directory
-func unzipFile(sourceFilePath string, destDirPath string) error {
+func unzipFile(sourceFilePath string, destDirPath string) (err error) {
 	// First, make sure the destination exists and is a directory
 	info, err := os.Stat(destDirPath)
 	if err != nil {
@@ -435,56 +488,95 @@ func unzipFile(sourceFilePath string, destDirPath string) error {
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := r.Close(); err != nil {
-			panic(err)
-		}
-	}()
+	defer closeAndSetErr(r, &err)
 
 	// Iterate through each file in the archive
 	for _, f := range r.File {
-		rc, err := f.Open()
-		if err != nil {
+		if err := unzipEntry(f, destDirPath); err != nil {
 			return err
 		}
-		defer func() {
-			if err := rc.Close(); err != nil {
-				panic(err)
-			}
-		}()
+	}
+
+	return nil
+}
 
-		destFilePath := filepath.Join(destDirPath, f.Name)
+// unzipEntry extracts a single zip entry into destDirPath, closing both
+// the archive reader and the destination file via closeAndSetErr so a
+// failure to close either surfaces as a returned error instead of a
+// panic.
+func unzipEntry(f *zip.File, destDirPath string) (err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer closeAndSetErr(rc, &err)
 
-		// Create destination subdirectories if necessary
-		destBaseDirPath, _ := filepath.Split(destFilePath)
-		os.MkdirAll(destBaseDirPath, testDirPerm)
+	destFilePath := filepath.Join(destDirPath, f.Name)
 
-		// Create the destination file
-		w, err := os.Create(destFilePath)
-		if err != nil {
-			return err
-		}
-		defer func() {
-			if err := w.Close(); err != nil {
-				panic(err)
-			}
-		}()
+	// Create destination subdirectories if necessary
+	destBaseDirPath, _ := filepath.Split(destFilePath)
+	os.MkdirAll(destBaseDirPath, testDirPerm)
 
-		// Copy the contents of the source file
-		_, err = io.Copy(w, rc)
-		if err != nil {
-			return err
-		}
+	// Create the destination file
+	w, err := os.Create(destFilePath)
+	if err != nil {
+		return err
 	}
+	defer closeAndSetErr(w, &err)
 
-	return nil
+	// Copy the contents of the source file
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+// testEpubcheckEnvVar, when set to a non-empty value, makes validateEpub
+// fall back to shelling out to epubcheck.jar instead of using the
+// in-process validator. Most runs shouldn't need this; it exists for
+// double-checking against the reference implementation.
+const testEpubcheckEnvVar = "GOEPUB_USE_EPUBCHECK"
+
+// validateEpub validates the EPUB at epubFilename, preferring the pure-Go
+// validate package so the test suite doesn't require a JRE. Set
+// GOEPUB_USE_EPUBCHECK to validate with the real epubcheck.jar instead.
+func validateEpub(t *testing.T, epubFilename string) ([]byte, error) {
+	if os.Getenv(testEpubcheckEnvVar) == "" {
+		return validateEpubInProcess(epubFilename)
+	}
+	return validateEpubWithEpubcheckJar(t, epubFilename)
+}
+
+func validateEpubInProcess(epubFilename string) ([]byte, error) {
+	f, err := os.Open(epubFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := validate.Validate(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	for _, issue := range report.Issues {
+		fmt.Fprintf(&out, "[%s] %s: %s (%s)\n", issue.Severity, issue.Code, issue.Message, issue.Path)
+	}
+	if report.HasErrors() {
+		return out.Bytes(), errors.New("EPUB validation failed")
+	}
+	return out.Bytes(), nil
 }
 
 // This function requires epubcheck to work (https://github.com/IDPF/epubcheck)
 //
 //     wget https://github.com/IDPF/epubcheck/releases/download/v4.0.1/epubcheck-4.0.1.zip
 //     unzip epubcheck-4.0.1.zip
-func validateEpub(t *testing.T, epubFilename string) ([]byte, error) {
+func validateEpubWithEpubcheckJar(t *testing.T, epubFilename string) ([]byte, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		t.Error("Error getting working directory")
@@ -522,8 +614,8 @@ func validateEpub(t *testing.T, epubFilename string) ([]byte, error) {
 	return cmd.CombinedOutput()
 }
 
-func writeAndExtractEpub(t *testing.T, e *Epub, epubFilename string) string {
-	tempDir, err := ioutil.TempDir("", tempDirPrefix)
+func writeAndExtractEpub(t *testing.T, e *epub, epubFilename string) string {
+	tempDir, err := ioutil.TempDir("", testTempDirPrefix)
 	if err != nil {
 		t.Errorf("Unexpected error creating temp dir: %s", err)
 	}
@@ -0,0 +1,122 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+const testWriteToTitle = "Streamed EPUB"
+
+func TestWriteToMimetypeIsFirstStoredEntry(t *testing.T) {
+	e, err := NewEpub(testWriteToTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := e.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo returned %d bytes, buffer holds %d", n, buf.Len())
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error reopening EPUB as zip: %s", err)
+	}
+	if len(r.File) == 0 {
+		t.Fatal("EPUB archive has no entries")
+	}
+
+	first := r.File[0]
+	if first.Name != mimetypeFilename {
+		t.Errorf("First zip entry is %q, expected %q", first.Name, mimetypeFilename)
+	}
+	if first.Method != zip.Store {
+		t.Errorf("mimetype entry method is %d, expected %d (stored)", first.Method, zip.Store)
+	}
+	if len(first.Extra) != 0 {
+		t.Errorf("mimetype entry has extra fields: %v", first.Extra)
+	}
+
+	rc, err := first.Open()
+	if err != nil {
+		t.Fatalf("Unexpected error opening mimetype entry: %s", err)
+	}
+	defer rc.Close()
+
+	contents := make([]byte, len(mimetypeContents))
+	if _, err := rc.Read(contents); err != nil {
+		t.Fatalf("Unexpected error reading mimetype entry: %s", err)
+	}
+	if string(contents) != mimetypeContents {
+		t.Errorf("mimetype entry contents are %q, expected %q", contents, mimetypeContents)
+	}
+}
+
+func TestWriteToReportsProgress(t *testing.T) {
+	e, err := NewEpub(testWriteToTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	var stages []string
+	e.SetProgressFunc(func(stage string, current, total int64) {
+		stages = append(stages, stage)
+		if current > total {
+			t.Errorf("progress current %d exceeds total %d", current, total)
+		}
+	})
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+
+	if len(stages) == 0 {
+		t.Fatal("ProgressFunc was never called")
+	}
+	if stages[0] != stageMimetype {
+		t.Errorf("first progress stage was %q, expected %q", stages[0], stageMimetype)
+	}
+}
+
+func TestWriteToReportsProgressPerAsset(t *testing.T) {
+	e, err := NewEpub(testWriteToTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	href, err := e.AddImage(testCoverImageSource, "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+
+	var stages []string
+	var lastTotal int64
+	e.SetProgressFunc(func(stage string, current, total int64) {
+		stages = append(stages, stage)
+		lastTotal = total
+		if current > total {
+			t.Errorf("progress current %d exceeds total %d", current, total)
+		}
+	})
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+
+	wantTotal := int64(totalWriteStages + 1)
+	if lastTotal != wantTotal {
+		t.Errorf("final progress total was %d, expected %d (fixed stages + 1 asset)", lastTotal, wantTotal)
+	}
+
+	last := stages[len(stages)-1]
+	if last != href {
+		t.Errorf("last progress stage was %q, expected the asset href %q", last, href)
+	}
+}
@@ -0,0 +1,70 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Sentinel errors identify the category of a failure returned by this
+// package. Use errors.Is against these rather than matching error strings
+// so callers embedding go-epub in a long-running server (where a panic on
+// a malformed asset would take down the process) can tell a malformed
+// input file apart from a network hiccup.
+var (
+	// ErrInvalidEPUB is returned when an archive passed to Read/ReadFrom
+	// isn't a well-formed EPUB (bad zip, missing or unparsable
+	// container.xml/package document).
+	ErrInvalidEPUB = errors.New("epub: invalid EPUB")
+
+	// ErrAssetFetch is returned when fetching a remote asset registered
+	// via AddImage/AddFont/AddCSS fails.
+	ErrAssetFetch = errors.New("epub: asset fetch failed")
+
+	// ErrMissingManifest is returned when something refers to a manifest
+	// entry that doesn't exist, such as Section with an unknown href.
+	ErrMissingManifest = errors.New("epub: missing manifest entry")
+
+	// ErrDuplicateFilename is returned by AddImage/AddFont/AddCSS when the
+	// requested filename collides with one already registered.
+	ErrDuplicateFilename = errors.New("epub: duplicate filename")
+)
+
+// taggedError pairs one of the sentinels above with the underlying cause,
+// so errors.Is(err, ErrAssetFetch) works via Is while the error message
+// and errors.Unwrap still surface the real cause.
+type taggedError struct {
+	sentinel error
+	cause    error
+}
+
+// tagError wraps cause so errors.Is(result, sentinel) succeeds, returning
+// nil if cause is nil.
+func tagError(sentinel, cause error) error {
+	if cause == nil {
+		return nil
+	}
+	return &taggedError{sentinel: sentinel, cause: cause}
+}
+
+func (e *taggedError) Error() string {
+	return fmt.Sprintf("%s: %s", e.sentinel, e.cause)
+}
+
+func (e *taggedError) Unwrap() error {
+	return e.cause
+}
+
+func (e *taggedError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+// closeAndSetErr closes c, assigning its error to *err if *err isn't
+// already set. Deferring this instead of closing inline means a close
+// failure surfaces as a returned error instead of being silently dropped
+// (or, worse, panicking) once the caller has already moved on.
+func closeAndSetErr(c io.Closer, err *error) {
+	if closeErr := c.Close(); closeErr != nil && *err == nil {
+		*err = closeErr
+	}
+}
@@ -0,0 +1,49 @@
+package epub
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTagErrorIsAndUnwrap(t *testing.T) {
+	cause := errors.New("boom")
+	err := tagError(ErrAssetFetch, cause)
+
+	if !errors.Is(err, ErrAssetFetch) {
+		t.Errorf("errors.Is(err, ErrAssetFetch) is false, expected true")
+	}
+	if errors.Is(err, ErrInvalidEPUB) {
+		t.Errorf("errors.Is(err, ErrInvalidEPUB) is true, expected false")
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Unwrap chain doesn't reach the original cause")
+	}
+}
+
+func TestSectionMissingIsErrMissingManifest(t *testing.T) {
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	_, err = e.Section("xhtml/does-not-exist.xhtml")
+	if !errors.Is(err, ErrMissingManifest) {
+		t.Errorf("Section on an unknown href returned %v, expected an ErrMissingManifest", err)
+	}
+}
+
+func TestAddImageDuplicateFilenameIsErrDuplicateFilename(t *testing.T) {
+	e, err := NewEpub(testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	if _, err := e.AddImage(testCoverImageSource, "same.png"); err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+
+	_, err = e.AddImage(testCoverImageSource, "same.png")
+	if !errors.Is(err, ErrDuplicateFilename) {
+		t.Errorf("Second AddImage with the same filename returned %v, expected an ErrDuplicateFilename", err)
+	}
+}
@@ -0,0 +1,143 @@
+package epub
+
+import (
+	"fmt"
+	"time"
+)
+
+// ManifestItem describes a single entry in the EPUB's manifest: a content
+// file (XHTML, image, font, CSS, ...) bundled inside the archive.
+type ManifestItem struct {
+	ID         string
+	Href       string
+	MediaType  string
+	Properties string
+}
+
+// pkgSpineItem is a single <itemref> in the package document's spine,
+// pointing at a manifest item by ID in reading order.
+type pkgSpineItem struct {
+	idref  string
+	linear string
+}
+
+const (
+	pkgTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="pub-id" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="pub-id">%s</dc:identifier>
+    <dc:title>%s</dc:title>
+    <dc:language>%s</dc:language>
+%s    <meta property="dcterms:modified">%s</meta>
+%s  </metadata>
+  <manifest>
+%s  </manifest>
+  <spine toc="ncx">
+%s  </spine>
+</package>`
+
+	pkgAuthorTemplate       = "    <dc:creator id=\"creator\">%s</dc:creator>\n"
+	pkgCoverMetaTemplate    = "    <meta name=\"cover\" content=\"%s\"/>\n"
+	pkgManifestItemTemplate = "    <item id=\"%s\" href=\"%s\" media-type=\"%s\"%s></item>\n"
+	pkgSpineItemTemplate    = "    <itemref idref=\"%s\"%s/>\n"
+)
+
+// pkgdoc models the EPUB package document (package.opf): the Dublin Core
+// metadata that describes the book, plus the manifest and spine of its
+// content files.
+type pkgdoc struct {
+	author      string
+	coverMetaID string
+	lang        string
+	manifest    []ManifestItem
+	spine       []pkgSpineItem
+	title       string
+	uuid        string
+}
+
+func newPkgdoc() *pkgdoc {
+	p := &pkgdoc{}
+	p.manifest = []ManifestItem{
+		{ID: "nav", Href: navFilename, MediaType: "application/xhtml+xml", Properties: "nav"},
+		{ID: "ncx", Href: tocNcxFilename, MediaType: "application/x-dtbncx+xml"},
+	}
+	return p
+}
+
+func (p *pkgdoc) setAuthor(author string) {
+	p.author = author
+}
+
+func (p *pkgdoc) setLang(lang string) {
+	p.lang = lang
+}
+
+func (p *pkgdoc) setTitle(title string) {
+	p.title = title
+}
+
+func (p *pkgdoc) setUUID(uuid string) {
+	p.uuid = uuid
+}
+
+// addManifestItem registers a content file in the manifest. It doesn't
+// deduplicate: callers (Add*, Read) are expected to pick unique IDs.
+func (p *pkgdoc) addManifestItem(id, href, mediaType, properties string) {
+	p.manifest = append(p.manifest, ManifestItem{ID: id, Href: href, MediaType: mediaType, Properties: properties})
+}
+
+// addSpineItem appends a reading-order entry. linear is "" (meaning "yes")
+// for ordinary content, or "no" for auxiliary pages like a cover that
+// shouldn't appear in the default reading order.
+func (p *pkgdoc) addSpineItem(idref, linear string) {
+	p.spine = append(p.spine, pkgSpineItem{idref: idref, linear: linear})
+}
+
+// addSpineItemFirst inserts a reading-order entry at the front of the
+// spine, for front matter like a cover page.
+func (p *pkgdoc) addSpineItemFirst(idref, linear string) {
+	p.spine = append([]pkgSpineItem{{idref: idref, linear: linear}}, p.spine...)
+}
+
+// setCoverMetaID records the manifest ID of the cover image so bytes can
+// emit the legacy EPUB2 <meta name="cover"> alongside the EPUB3
+// properties="cover-image" manifest attribute, for readers that only
+// understand one or the other.
+func (p *pkgdoc) setCoverMetaID(id string) {
+	p.coverMetaID = id
+}
+
+// bytes renders the package document to its on-disk XML form.
+func (p *pkgdoc) bytes() ([]byte, error) {
+	var author string
+	if p.author != "" {
+		author = fmt.Sprintf(pkgAuthorTemplate, xmlEscapeText(p.author))
+	}
+
+	var manifest string
+	for _, item := range p.manifest {
+		var properties string
+		if item.Properties != "" {
+			properties = fmt.Sprintf(` properties="%s"`, item.Properties)
+		}
+		manifest += fmt.Sprintf(pkgManifestItemTemplate, item.ID, item.Href, item.MediaType, properties)
+	}
+
+	var spine string
+	for _, item := range p.spine {
+		var linear string
+		if item.linear != "" {
+			linear = fmt.Sprintf(` linear="%s"`, item.linear)
+		}
+		spine += fmt.Sprintf(pkgSpineItemTemplate, item.idref, linear)
+	}
+
+	modified := time.Now().UTC().Format(time.RFC3339)
+
+	var coverMeta string
+	if p.coverMetaID != "" {
+		coverMeta = fmt.Sprintf(pkgCoverMetaTemplate, p.coverMetaID)
+	}
+
+	return []byte(fmt.Sprintf(pkgTemplate, p.uuid, xmlEscapeText(p.title), p.lang, author, modified, coverMeta, manifest, spine)), nil
+}
@@ -0,0 +1,298 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// containerXML models META-INF/container.xml, which does nothing more
+// than point readers at the package document.
+type containerXML struct {
+	XMLName   xml.Name `xml:"container"`
+	Rootfiles struct {
+		Rootfile []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfile"`
+	} `xml:"rootfiles"`
+}
+
+// opfXML models the subset of the package document this package knows how
+// to round-trip: identifier/title/language/creator metadata, the manifest,
+// and the spine.
+type opfXML struct {
+	XMLName          xml.Name `xml:"package"`
+	UniqueIdentifier string   `xml:"unique-identifier,attr"`
+	Metadata         struct {
+		Identifier []struct {
+			ID      string `xml:"id,attr"`
+			Content string `xml:",chardata"`
+		} `xml:"identifier"`
+		Title    string `xml:"title"`
+		Language string `xml:"language"`
+		Creator  string `xml:"creator"`
+		Meta     []struct {
+			Name    string `xml:"name,attr"`
+			Content string `xml:"content,attr"`
+		} `xml:"meta"`
+	} `xml:"metadata"`
+	Manifest struct {
+		Item []struct {
+			ID         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			MediaType  string `xml:"media-type,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"item"`
+	} `xml:"manifest"`
+	Spine struct {
+		Itemref []struct {
+			IDref  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// ncxXML models just enough of the legacy NCX to recover the table of
+// contents entries written there by this package.
+type ncxXML struct {
+	NavMap struct {
+		NavPoint []struct {
+			NavLabel struct {
+				Text string `xml:"text"`
+			} `xml:"navLabel"`
+			Content struct {
+				Src string `xml:"src,attr"`
+			} `xml:"content"`
+		} `xml:"navPoint"`
+	} `xml:"navMap"`
+}
+
+// navXML models just enough of the EPUB3 nav document to recover the table
+// of contents entries written there by this package. A book with no NCX
+// (a pure EPUB3 book, rather than one this package wrote) only has this to
+// recover the TOC from.
+type navXML struct {
+	Body struct {
+		Nav []struct {
+			Type string `xml:"type,attr"`
+			Ol   struct {
+				Li []struct {
+					A struct {
+						Href string `xml:"href,attr"`
+						Text string `xml:",chardata"`
+					} `xml:"a"`
+				} `xml:"li"`
+			} `xml:"ol"`
+		} `xml:"nav"`
+	} `xml:"body"`
+}
+
+// Read loads an existing EPUB from the file at path, so callers can inspect
+// or mutate it and write it back out with Write/WriteTo.
+func Read(path string) (result *epub, err error) {
+	f, openErr := os.Open(path)
+	if openErr != nil {
+		return nil, fmt.Errorf("opening EPUB file: %w", openErr)
+	}
+	defer closeAndSetErr(f, &err)
+
+	info, statErr := f.Stat()
+	if statErr != nil {
+		return nil, fmt.Errorf("statting EPUB file: %w", statErr)
+	}
+
+	result, err = ReadFrom(f, info.Size())
+	return result, err
+}
+
+// ReadFrom parses an EPUB from r, which must support random access into an
+// archive of the given size. It's the basis for Read, and lets callers
+// parse EPUBs already held in memory or fetched into a ReaderAt without
+// writing them to disk first.
+func ReadFrom(r io.ReaderAt, size int64) (*epub, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, tagError(ErrInvalidEPUB, fmt.Errorf("opening EPUB archive: %w", err))
+	}
+
+	containerData, err := readZipFile(zr, filepath.Join(metaInfFolderName, containerFilename))
+	if err != nil {
+		return nil, tagError(ErrInvalidEPUB, err)
+	}
+	var container containerXML
+	if err := xml.Unmarshal(containerData, &container); err != nil {
+		return nil, tagError(ErrInvalidEPUB, fmt.Errorf("parsing container.xml: %w", err))
+	}
+	if len(container.Rootfiles.Rootfile) == 0 {
+		return nil, tagError(ErrInvalidEPUB, fmt.Errorf("container.xml lists no rootfile"))
+	}
+	rootfilePath := container.Rootfiles.Rootfile[0].FullPath
+	rootDir := filepath.Dir(rootfilePath)
+
+	opfData, err := readZipFile(zr, rootfilePath)
+	if err != nil {
+		return nil, tagError(ErrInvalidEPUB, err)
+	}
+	var opf opfXML
+	if err := xml.Unmarshal(opfData, &opf); err != nil {
+		return nil, tagError(ErrInvalidEPUB, fmt.Errorf("parsing package document: %w", err))
+	}
+
+	e := &epub{assets: map[string][]byte{}}
+	e.pkgdoc = newPkgdoc()
+	e.pkgdoc.manifest = nil
+	e.toc, err = newToc()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range opf.Metadata.Identifier {
+		if id.ID == opf.UniqueIdentifier {
+			e.SetUUID(id.Content)
+		}
+	}
+	e.SetTitle(opf.Metadata.Title)
+	e.SetLang(opf.Metadata.Language)
+	if opf.Metadata.Creator != "" {
+		e.SetAuthor(opf.Metadata.Creator)
+	}
+
+	// The nav document and NCX are identified canonically by their
+	// properties/media-type, not by assuming the source used this
+	// package's own filenames. Both are regenerated from e.toc on Write,
+	// always at this package's own navFilename/tocNcxFilename paths, so
+	// their manifest entries are added separately below rather than kept
+	// as-read, and their original hrefs (which is where their actual
+	// content lives in the source archive) are only needed to recover the
+	// TOC from them further down.
+	navHref, ncxHref, coverImageID := "", "", ""
+	for _, item := range opf.Manifest.Item {
+		if item.Properties == "nav" {
+			navHref = item.Href
+			continue
+		}
+		if item.MediaType == "application/x-dtbncx+xml" {
+			ncxHref = item.Href
+			continue
+		}
+		if strings.Contains(item.Properties, "cover-image") {
+			coverImageID = item.ID
+		}
+
+		e.pkgdoc.addManifestItem(item.ID, item.Href, item.MediaType, item.Properties)
+
+		data, err := readZipFile(zr, filepath.Join(rootDir, item.Href))
+		if err != nil {
+			return nil, tagError(ErrMissingManifest, fmt.Errorf("reading manifest item %s: %w", item.Href, err))
+		}
+		e.assets[item.Href] = data
+	}
+	e.pkgdoc.addManifestItem("nav", navFilename, "application/xhtml+xml", "nav")
+	e.pkgdoc.addManifestItem("ncx", tocNcxFilename, "application/x-dtbncx+xml", "")
+
+	// The EPUB3 manifest property is the primary signal; fall back to the
+	// legacy EPUB2 <meta name="cover"> for books that only carry that
+	// form, so the cover survives a read/write round-trip either way.
+	if coverImageID == "" {
+		for _, m := range opf.Metadata.Meta {
+			if m.Name == "cover" {
+				coverImageID = m.Content
+				break
+			}
+		}
+	}
+	if coverImageID != "" {
+		e.pkgdoc.setCoverMetaID(coverImageID)
+		// The cover XHTML page is always written at this fixed path by
+		// SetCover, so a book this package produced has its cover here
+		// regardless of what the rest of its layout looked like.
+		coverHref := filepath.ToSlash(filepath.Join(xhtmlFolderName, coverXhtmlFilename))
+		if _, ok := e.assets[coverHref]; ok {
+			e.cover = coverHref
+		}
+	}
+
+	if navHref == "" {
+		navHref = navFilename
+	}
+	if ncxHref == "" {
+		ncxHref = tocNcxFilename
+	}
+
+	for _, ref := range opf.Spine.Itemref {
+		e.pkgdoc.addSpineItem(ref.IDref, ref.Linear)
+	}
+
+	tocRecovered := false
+	if ncxData, err := readZipFile(zr, filepath.Join(rootDir, ncxHref)); err == nil {
+		var ncx ncxXML
+		if err := xml.Unmarshal(ncxData, &ncx); err == nil {
+			for _, navPoint := range ncx.NavMap.NavPoint {
+				e.toc.addEntry(navPoint.NavLabel.Text, navPoint.Content.Src)
+			}
+			tocRecovered = len(ncx.NavMap.NavPoint) > 0
+		}
+	}
+
+	// A book with no NCX (a pure EPUB3 book, rather than one this package
+	// wrote) still has its TOC in the nav document, so fall back to that
+	// rather than silently dropping it.
+	if !tocRecovered {
+		if navData, err := readZipFile(zr, filepath.Join(rootDir, navHref)); err == nil {
+			var nav navXML
+			if err := xml.Unmarshal(navData, &nav); err == nil {
+				for _, n := range nav.Body.Nav {
+					if n.Type != "toc" {
+						continue
+					}
+					for _, li := range n.Ol.Li {
+						e.toc.addEntry(li.A.Text, li.A.Href)
+					}
+				}
+			}
+		}
+	}
+
+	return e, nil
+}
+
+// MediaBag returns the EPUB's manifest: every content file (XHTML, image,
+// font, CSS, ...) bundled inside the archive, without having to unzip it
+// by hand.
+func (e *epub) MediaBag() []ManifestItem {
+	items := make([]ManifestItem, len(e.pkgdoc.manifest))
+	copy(items, e.pkgdoc.manifest)
+	return items
+}
+
+// Section returns the raw contents of a manifest entry by its href, as
+// found in MediaBag. It's how callers inspect embedded XHTML or images
+// without unzipping the EPUB themselves.
+func (e *epub) Section(href string) ([]byte, error) {
+	data, ok := e.assets[href]
+	if !ok {
+		return nil, tagError(ErrMissingManifest, fmt.Errorf("section %q not found in EPUB", href))
+	}
+	return data, nil
+}
+
+// readZipFile returns the contents of the archive entry at name.
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	name = filepath.ToSlash(name)
+	for _, f := range zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("opening %s: %w", name, err)
+			}
+			defer rc.Close()
+			return ioutil.ReadAll(rc)
+		}
+	}
+	return nil, fmt.Errorf("%s not found in EPUB archive", name)
+}
@@ -0,0 +1,153 @@
+package epub
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fmartingr/go-epub/validate"
+)
+
+const testReadTitle = "Round Tripped EPUB"
+
+func TestReadFromRoundTripsMetadata(t *testing.T) {
+	e, err := NewEpub(testReadTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+	e.SetAuthor(testEpubAuthor)
+	e.SetLang(testEpubLang)
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+
+	got, err := ReadFrom(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB: %s", err)
+	}
+
+	if got.Title() != e.Title() {
+		t.Errorf("Title is %q, expected %q", got.Title(), e.Title())
+	}
+	if got.Lang() != testEpubLang {
+		t.Errorf("Lang is %q, expected %q", got.Lang(), testEpubLang)
+	}
+	if got.UUID() != e.UUID() {
+		t.Errorf("UUID is %q, expected %q", got.UUID(), e.UUID())
+	}
+
+	mediaBag := got.MediaBag()
+	if len(mediaBag) != 2 {
+		t.Fatalf("MediaBag has %d items, expected 2 (nav + ncx)", len(mediaBag))
+	}
+}
+
+func TestReadFromRoundTripsCover(t *testing.T) {
+	e, err := NewEpub(testReadTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+	coverHref, err := e.SetCover(testCoverImageSource, "")
+	if err != nil {
+		t.Fatalf("Unexpected error setting cover: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+
+	got, err := ReadFrom(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB: %s", err)
+	}
+	if got.Cover() != coverHref {
+		t.Errorf("Cover() is %q after round-tripping, expected %q", got.Cover(), coverHref)
+	}
+
+	var buf2 bytes.Buffer
+	if _, err := got.WriteTo(&buf2); err != nil {
+		t.Fatalf("Unexpected error writing round-tripped EPUB: %s", err)
+	}
+
+	pkg, err := got.pkgdoc.bytes()
+	if err != nil {
+		t.Fatalf("Unexpected error rendering package document: %s", err)
+	}
+	if !strings.Contains(string(pkg), `<meta name="cover" content="cover-image"/>`) {
+		t.Errorf("legacy EPUB2 cover meta element was dropped after round-tripping:\n%s", pkg)
+	}
+}
+
+func TestReadFromThenAddAssetDoesNotCollide(t *testing.T) {
+	e, err := NewEpub(testReadTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+	if _, err := e.AddImage(testCoverImageSource, ""); err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+
+	got, err := ReadFrom(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB: %s", err)
+	}
+
+	if _, err := got.AddImage(testCoverImageSource, ""); err != nil {
+		t.Errorf("Unexpected error adding a second auto-named image to a loaded book: %s", err)
+	}
+}
+
+// sampleFixtures covers books this package didn't itself write: EPUB3 books
+// with only a nav document (no legacy NCX) and EPUB2 books with only an
+// NCX (no nav document), each with and without a cover. Reading one of
+// these and writing it back out must produce a file the validator is happy
+// with, and must not silently drop the table of contents.
+var sampleFixtures = []string{
+	"testdata/sample-epub3.epub",
+	"testdata/sample-epub3-with-cover.epub",
+	"testdata/sample-epub2.epub",
+	"testdata/sample-epub2-with-cover.epub",
+	"testdata/sample-epub3-custom-nav-filename.epub",
+}
+
+func TestReadFromRoundTripsSampleFixtures(t *testing.T) {
+	for _, path := range sampleFixtures {
+		t.Run(path, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Unexpected error reading fixture: %s", err)
+			}
+
+			got, err := ReadFrom(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("Unexpected error reading EPUB: %s", err)
+			}
+
+			if len(got.toc.entries) == 0 {
+				t.Error("table of contents is empty after round-tripping the fixture")
+			}
+
+			var buf bytes.Buffer
+			if _, err := got.WriteTo(&buf); err != nil {
+				t.Fatalf("Unexpected error writing EPUB back out: %s", err)
+			}
+
+			report, err := validate.Validate(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("Unexpected error validating round-tripped EPUB: %s", err)
+			}
+			if report.HasErrors() {
+				t.Errorf("round-tripped EPUB has validation errors: %+v", report.Issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package epub
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	sectionFilenameTemplate = "section%04d.xhtml"
+
+	sectionTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+  <head>
+    <title>%s</title>
+  </head>
+  <body>
+    %s
+  </body>
+</html>`
+)
+
+// AddSection adds a new section (e.g. a chapter) to the book, wrapping
+// body in a minimal XHTML document titled title. filename is used as the
+// section's filename, or a generated one if filename is "". Sections are
+// appended to the spine and table of contents in the order they're added.
+// It returns the filename the section was stored under.
+func (e *epub) AddSection(title, body, filename string) (string, error) {
+	if filename == "" {
+		filename = e.nextSectionFilename()
+	}
+
+	href := filepath.ToSlash(filepath.Join(xhtmlFolderName, filename))
+	if e.hasAsset(href) {
+		return "", tagError(ErrDuplicateFilename, fmt.Errorf("%q is already registered", href))
+	}
+
+	e.assets[href] = []byte(fmt.Sprintf(sectionTemplate, xmlEscapeText(title), body))
+	e.pkgdoc.addManifestItem(manifestIDForHref(href), href, "application/xhtml+xml", "")
+	e.pkgdoc.addSpineItem(manifestIDForHref(href), "")
+	e.toc.addEntry(title, href)
+
+	return filename, nil
+}
+
+// nextSectionFilename generates the next unused auto-assigned section
+// filename, so it doesn't collide with an explicit filename used for an
+// earlier section.
+func (e *epub) nextSectionFilename() string {
+	for {
+		e.sectionCount++
+		filename := fmt.Sprintf(sectionFilenameTemplate, e.sectionCount)
+		href := filepath.ToSlash(filepath.Join(xhtmlFolderName, filename))
+		if !e.hasAsset(href) {
+			return filename
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package epub
+
+import "fmt"
+
+const (
+	navTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+  <head>
+    <title>%s</title>
+  </head>
+  <body>
+    <nav epub:type="toc">
+      <h1>%s</h1>
+      <ol>
+%s      </ol>
+    </nav>
+  </body>
+</html>`
+
+	ncxTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="%s"/>
+  </head>
+  <docTitle>
+    <text>%s</text>
+  </docTitle>
+  <navMap>
+%s  </navMap>
+</ncx>`
+
+	navPointTemplate    = "    <li><a href=\"%s\">%s</a></li>\n"
+	ncxNavPointTemplate = `    <navPoint id="%s" playOrder="%d">
+      <navLabel>
+        <text>%s</text>
+      </navLabel>
+      <content src="%s"/>
+    </navPoint>
+`
+)
+
+// tocEntry is a single link shared by both the EPUB3 nav document and the
+// legacy EPUB2 NCX, pointing at a section added to the book.
+type tocEntry struct {
+	href  string
+	title string
+}
+
+// toc tracks the book's table of contents and renders it as both the
+// EPUB3 nav document and the EPUB2 NCX, so the EPUB works in readers that
+// only understand one or the other.
+type toc struct {
+	entries []tocEntry
+	title   string
+	uuid    string
+}
+
+func newToc() (*toc, error) {
+	return &toc{}, nil
+}
+
+func (t *toc) setTitle(title string) {
+	t.title = title
+}
+
+func (t *toc) setUUID(uuid string) {
+	t.uuid = uuid
+}
+
+func (t *toc) addEntry(title, href string) {
+	t.entries = append(t.entries, tocEntry{href: href, title: title})
+}
+
+// addEntryFirst inserts an entry at the front of the table of contents, for
+// front matter like a cover page that must lead the reading order.
+func (t *toc) addEntryFirst(title, href string) {
+	t.entries = append([]tocEntry{{href: href, title: title}}, t.entries...)
+}
+
+// navBytes renders the EPUB3 nav document (nav.xhtml).
+func (t *toc) navBytes() ([]byte, error) {
+	var items string
+	for _, e := range t.entries {
+		items += fmt.Sprintf(navPointTemplate, e.href, xmlEscapeText(e.title))
+	}
+
+	title := xmlEscapeText(t.title)
+	return []byte(fmt.Sprintf(navTemplate, title, title, items)), nil
+}
+
+// ncxBytes renders the legacy EPUB2 NCX document (toc.ncx), kept alongside
+// the nav document for readers that predate EPUB3.
+func (t *toc) ncxBytes() ([]byte, error) {
+	var navPoints string
+	for i, e := range t.entries {
+		navPoints += fmt.Sprintf(ncxNavPointTemplate, fmt.Sprintf("navPoint-%d", i+1), i+1, xmlEscapeText(e.title), e.href)
+	}
+
+	return []byte(fmt.Sprintf(ncxTemplate, t.uuid, xmlEscapeText(t.title), navPoints)), nil
+}
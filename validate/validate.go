@@ -0,0 +1,330 @@
+// Package validate performs the structural EPUB checks that epubcheck.jar
+// has historically covered for this library's own tests, in pure Go, so
+// CI and local test runs don't need a JRE installed.
+package validate
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// Severity classifies how serious an Issue is. Only SeverityError causes
+// Report.HasErrors to return true; SeverityWarning is informational.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Issue is a single problem found while validating an EPUB.
+type Issue struct {
+	Severity Severity
+	Code     string
+	Path     string
+	Message  string
+}
+
+// Report is the result of a Validate call.
+type Report struct {
+	Issues []Issue
+}
+
+// HasErrors reports whether the report contains any SeverityError issues.
+func (r *Report) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) add(severity Severity, code, path, message string) {
+	r.Issues = append(r.Issues, Issue{Severity: severity, Code: code, Path: path, Message: message})
+}
+
+const mimetypeContents = "application/epub+zip"
+
+type containerXML struct {
+	XMLName   xml.Name `xml:"container"`
+	Rootfiles struct {
+		Rootfile []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfile"`
+	} `xml:"rootfiles"`
+}
+
+type opfXML struct {
+	XMLName          xml.Name `xml:"package"`
+	UniqueIdentifier string   `xml:"unique-identifier,attr"`
+	Metadata         struct {
+		Identifier []struct {
+			ID      string `xml:"id,attr"`
+			Content string `xml:",chardata"`
+		} `xml:"identifier"`
+		Meta []struct {
+			Property string `xml:"property,attr"`
+			Content  string `xml:",chardata"`
+		} `xml:"meta"`
+	} `xml:"metadata"`
+	Manifest struct {
+		Item []struct {
+			ID         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"item"`
+	} `xml:"manifest"`
+	Spine struct {
+		Itemref []struct {
+			IDref string `xml:"idref,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// Validate checks an EPUB archive of size bytes, read through r, against
+// the minimum structural ruleset this library relies on: the mimetype
+// entry, container.xml, the package document's identifier/manifest/spine
+// consistency, dcterms:modified, the nav document's toc landmark, and that
+// every XHTML content file is well-formed XML.
+func Validate(r io.ReaderAt, size int64) (*Report, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("opening EPUB archive: %w", err)
+	}
+
+	report := &Report{}
+
+	checkMimetype(report, zr)
+
+	rootfilePath, ok := checkContainer(report, zr)
+	if !ok {
+		return report, nil
+	}
+
+	opf, ok := checkPackageDocument(report, zr, rootfilePath)
+	if !ok {
+		return report, nil
+	}
+
+	checkNav(report, zr, opf, rootfilePath)
+	checkXHTMLWellFormed(report, zr)
+
+	return report, nil
+}
+
+func checkMimetype(report *Report, zr *zip.Reader) {
+	if len(zr.File) == 0 {
+		report.add(SeverityError, "RSC-001", "mimetype", "archive is empty")
+		return
+	}
+
+	first := zr.File[0]
+	if first.Name != "mimetype" {
+		report.add(SeverityError, "RSC-001", first.Name, "mimetype must be the first entry in the archive")
+		return
+	}
+	if first.Method != zip.Store {
+		report.add(SeverityError, "RSC-001", "mimetype", "mimetype entry must be stored, not compressed")
+	}
+
+	rc, err := first.Open()
+	if err != nil {
+		report.add(SeverityError, "RSC-001", "mimetype", fmt.Sprintf("opening mimetype entry: %s", err))
+		return
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		report.add(SeverityError, "RSC-001", "mimetype", fmt.Sprintf("reading mimetype entry: %s", err))
+		return
+	}
+	if string(data) != mimetypeContents {
+		report.add(SeverityError, "RSC-001", "mimetype", fmt.Sprintf("contents are %q, expected %q", data, mimetypeContents))
+	}
+}
+
+func checkContainer(report *Report, zr *zip.Reader) (string, bool) {
+	const path = "META-INF/container.xml"
+
+	data, err := readZipFile(zr, path)
+	if err != nil {
+		report.add(SeverityError, "RSC-002", path, err.Error())
+		return "", false
+	}
+
+	var container containerXML
+	if err := xml.Unmarshal(data, &container); err != nil {
+		report.add(SeverityError, "RSC-002", path, fmt.Sprintf("parsing container.xml: %s", err))
+		return "", false
+	}
+
+	if len(container.Rootfiles.Rootfile) == 0 {
+		report.add(SeverityError, "RSC-002", path, "container.xml lists no rootfile")
+		return "", false
+	}
+
+	rootfilePath := container.Rootfiles.Rootfile[0].FullPath
+	if _, err := readZipFile(zr, rootfilePath); err != nil {
+		report.add(SeverityError, "RSC-002", path, fmt.Sprintf("rootfile %q does not exist in the archive", rootfilePath))
+		return "", false
+	}
+
+	return rootfilePath, true
+}
+
+func checkPackageDocument(report *Report, zr *zip.Reader, rootfilePath string) (*opfXML, bool) {
+	data, err := readZipFile(zr, rootfilePath)
+	if err != nil {
+		report.add(SeverityError, "RSC-003", rootfilePath, err.Error())
+		return nil, false
+	}
+
+	var opf opfXML
+	if err := xml.Unmarshal(data, &opf); err != nil {
+		report.add(SeverityError, "RSC-003", rootfilePath, fmt.Sprintf("parsing package document: %s", err))
+		return nil, false
+	}
+
+	identifierFound := false
+	for _, id := range opf.Metadata.Identifier {
+		if id.ID == opf.UniqueIdentifier {
+			identifierFound = true
+			break
+		}
+	}
+	if !identifierFound {
+		report.add(SeverityError, "RSC-005", rootfilePath, fmt.Sprintf("unique-identifier %q does not resolve to a dc:identifier element", opf.UniqueIdentifier))
+	}
+
+	manifestIDs := map[string]string{}
+	for _, item := range opf.Manifest.Item {
+		manifestIDs[item.ID] = item.Href
+
+		href := resolveHref(rootfilePath, item.Href)
+		if _, err := readZipFile(zr, href); err != nil {
+			report.add(SeverityError, "RSC-006", href, fmt.Sprintf("manifest item %q does not exist in the archive", item.Href))
+		}
+	}
+
+	for _, ref := range opf.Spine.Itemref {
+		if _, ok := manifestIDs[ref.IDref]; !ok {
+			report.add(SeverityError, "RSC-007", rootfilePath, fmt.Sprintf("spine idref %q does not resolve to a manifest item", ref.IDref))
+		}
+	}
+
+	modified := ""
+	for _, meta := range opf.Metadata.Meta {
+		if meta.Property == "dcterms:modified" {
+			modified = meta.Content
+			break
+		}
+	}
+	if modified == "" {
+		report.add(SeverityError, "RSC-008", rootfilePath, "dcterms:modified meta element is missing")
+	} else if _, err := time.Parse(time.RFC3339, modified); err != nil {
+		report.add(SeverityError, "RSC-008", rootfilePath, fmt.Sprintf("dcterms:modified %q is not RFC3339: %s", modified, err))
+	}
+
+	return &opf, true
+}
+
+func checkNav(report *Report, zr *zip.Reader, opf *opfXML, rootfilePath string) {
+	var navHref string
+	for _, item := range opf.Manifest.Item {
+		if strings.Contains(item.Properties, "nav") {
+			navHref = item.Href
+			break
+		}
+	}
+	if navHref == "" {
+		report.add(SeverityError, "RSC-009", rootfilePath, "manifest has no nav document")
+		return
+	}
+
+	path := resolveHref(rootfilePath, navHref)
+	data, err := readZipFile(zr, path)
+	if err != nil {
+		report.add(SeverityError, "RSC-009", path, err.Error())
+		return
+	}
+
+	contents := string(data)
+	if !strings.Contains(contents, `epub:type="toc"`) {
+		report.add(SeverityError, "RSC-009", path, `nav document has no <nav epub:type="toc"> element`)
+		return
+	}
+	// A book with an empty spine has nothing to link to yet, so an empty
+	// toc nav isn't a structural defect in that case.
+	if len(opf.Spine.Itemref) == 0 {
+		return
+	}
+	if !strings.Contains(contents, "<a ") && !strings.Contains(contents, "<a>") {
+		report.add(SeverityError, "RSC-009", path, "toc nav has no links")
+	}
+}
+
+func checkXHTMLWellFormed(report *Report, zr *zip.Reader) {
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, ".xhtml") && !strings.HasSuffix(f.Name, ".html") {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			report.add(SeverityError, "RSC-010", f.Name, fmt.Sprintf("opening: %s", err))
+			continue
+		}
+
+		decoder := xml.NewDecoder(rc)
+		var parseErr error
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if err != io.EOF {
+					parseErr = err
+				}
+				break
+			}
+		}
+		rc.Close()
+
+		if parseErr != nil {
+			report.add(SeverityError, "RSC-010", f.Name, fmt.Sprintf("not well-formed XML: %s", parseErr))
+		}
+	}
+}
+
+func resolveHref(rootfilePath, href string) string {
+	idx := strings.LastIndex(rootfilePath, "/")
+	if idx == -1 {
+		return href
+	}
+	return rootfilePath[:idx+1] + href
+}
+
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("opening %s: %w", name, err)
+			}
+			defer rc.Close()
+			return ioutil.ReadAll(rc)
+		}
+	}
+	return nil, fmt.Errorf("%s not found in EPUB archive", name)
+}
@@ -0,0 +1,150 @@
+package validate_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	epub "github.com/fmartingr/go-epub"
+	"github.com/fmartingr/go-epub/validate"
+)
+
+func TestValidateCleanEpub(t *testing.T) {
+	e, err := epub.NewEpub("Validate Me")
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+
+	report, err := validate.Validate(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error validating EPUB: %s", err)
+	}
+	if report.HasErrors() {
+		t.Errorf("Expected no validation errors, got: %+v", report.Issues)
+	}
+}
+
+func TestValidateCatchesMisplacedMimetype(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// Write something else first, so mimetype isn't the first entry.
+	w, err := zw.Create("README")
+	if err != nil {
+		t.Fatalf("Unexpected error creating entry: %s", err)
+	}
+	w.Write([]byte("not an epub yet"))
+
+	w, err = zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		t.Fatalf("Unexpected error creating entry: %s", err)
+	}
+	w.Write([]byte("application/epub+zip"))
+
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Unexpected error closing zip: %s", err)
+	}
+
+	report, err := validate.Validate(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error validating EPUB: %s", err)
+	}
+	if !report.HasErrors() {
+		t.Fatal("Expected a validation error for a misplaced mimetype entry, got none")
+	}
+
+	found := false
+	for _, issue := range report.Issues {
+		if issue.Code == "RSC-001" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected an RSC-001 issue, got: %+v", report.Issues)
+	}
+}
+
+// TestValidateFindsNavByProperty guards against matching the nav document
+// by this library's own manifest-ID convention (item id="nav") instead of
+// the spec-correct properties="nav" attribute, which would spuriously
+// reject any EPUB authored by another tool that IDs its nav item
+// differently.
+func TestValidateFindsNavByProperty(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		t.Fatalf("Unexpected error creating entry: %s", err)
+	}
+	w.Write([]byte("application/epub+zip"))
+
+	w, err = zw.Create("META-INF/container.xml")
+	if err != nil {
+		t.Fatalf("Unexpected error creating entry: %s", err)
+	}
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="EPUB/package.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`))
+
+	w, err = zw.Create("EPUB/package.opf")
+	if err != nil {
+		t.Fatalf("Unexpected error creating entry: %s", err)
+	}
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="pub-id" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="pub-id">urn:uuid:00000000-0000-0000-0000-000000000000</dc:identifier>
+    <dc:title>Other Tool's Book</dc:title>
+    <meta property="dcterms:modified">2020-01-01T00:00:00Z</meta>
+  </metadata>
+  <manifest>
+    <item id="section1" href="section1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="toc-nav" href="toc-nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+  </manifest>
+  <spine>
+    <itemref idref="section1"/>
+  </spine>
+</package>`))
+
+	w, err = zw.Create("EPUB/section1.xhtml")
+	if err != nil {
+		t.Fatalf("Unexpected error creating entry: %s", err)
+	}
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><html xmlns="http://www.w3.org/1999/xhtml"><body>Hi</body></html>`))
+
+	w, err = zw.Create("EPUB/toc-nav.xhtml")
+	if err != nil {
+		t.Fatalf("Unexpected error creating entry: %s", err)
+	}
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+  <body>
+    <nav epub:type="toc">
+      <ol><li><a href="section1.xhtml">Section 1</a></li></ol>
+    </nav>
+  </body>
+</html>`))
+
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Unexpected error closing zip: %s", err)
+	}
+
+	report, err := validate.Validate(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error validating EPUB: %s", err)
+	}
+	for _, issue := range report.Issues {
+		if issue.Code == "RSC-009" {
+			t.Errorf("Expected no RSC-009 issue for a nav item not id'd \"nav\", got: %+v", report.Issues)
+		}
+	}
+}